@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogFormatEnvVar selects the default encoding NewLogger builds with, so a
+// service can switch to structured logs for a production log aggregator
+// without a code change: FEATUREFORM_LOG_FORMAT=json.
+const LogFormatEnvVar = "FEATUREFORM_LOG_FORMAT"
+
+const (
+	EncodingJSON    = "json"
+	EncodingConsole = "console"
+)
+
+// Config selects how NewLoggerFromConfig builds its underlying zap core.
+type Config struct {
+	// Encoding is "json" or "console". Defaults to the value of
+	// LogFormatEnvVar, falling back to EncodingConsole.
+	Encoding string
+	Level    zapcore.Level
+	// Sampling enables zap's default log sampling, which is worth turning on
+	// for high-volume Info/Debug logging in production but makes tests
+	// relying on every line being observed flaky, so it defaults to off.
+	Sampling    bool
+	OutputPaths []string
+}
+
+func encodingFromEnv() string {
+	if strings.EqualFold(os.Getenv(LogFormatEnvVar), EncodingJSON) {
+		return EncodingJSON
+	}
+	return EncodingConsole
+}
+
+// NewLoggerFromConfig builds a Logger whose underlying zap core is
+// configured explicitly, rather than the console-only zap.NewDevelopment
+// NewLogger defaults to. Use this in services that ship logs to an
+// aggregator expecting one JSON object per line.
+func NewLoggerFromConfig(service string, cfg Config) Logger {
+	if cfg.Encoding == "" {
+		cfg.Encoding = encodingFromEnv()
+	}
+	if len(cfg.OutputPaths) == 0 {
+		cfg.OutputPaths = []string{"stdout"}
+	}
+
+	encoderCfg := zap.NewProductionEncoderConfig()
+	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+	if cfg.Encoding == EncodingConsole {
+		encoderCfg = zap.NewDevelopmentEncoderConfig()
+	}
+
+	zapCfg := zap.Config{
+		Encoding:         cfg.Encoding,
+		Level:            zap.NewAtomicLevelAt(cfg.Level),
+		Development:      cfg.Encoding == EncodingConsole,
+		OutputPaths:      cfg.OutputPaths,
+		ErrorOutputPaths: []string{"stderr"},
+		EncoderConfig:    encoderCfg,
+	}
+	if cfg.Sampling {
+		zapCfg.Sampling = &zap.SamplingConfig{Initial: 100, Thereafter: 100}
+	}
+
+	baseLogger, err := zapCfg.Build()
+	if err != nil {
+		panic(err)
+	}
+	return Logger{
+		SugaredLogger: baseLogger.Sugar().Named(service),
+		Values:        &sync.Map{},
+	}
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// readLoggedLine builds a logger with cfg pointed at a temp file, logs a
+// single line, and returns that file's contents so callers can assert on
+// the actual encoded bytes zap wrote.
+func readLoggedLine(t *testing.T, cfg Config) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "out.log")
+	cfg.OutputPaths = []string{path}
+	cfg.Level = zapcore.DebugLevel
+
+	logger := NewLoggerFromConfig("config-test", cfg)
+	logger.Info("hello")
+	_ = logger.Sync()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read logged output: %v", err)
+	}
+	return string(data)
+}
+
+func TestNewLoggerFromConfigJSONEncodingProducesParseableJSON(t *testing.T) {
+	line := readLoggedLine(t, Config{Encoding: EncodingJSON})
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &parsed); err != nil {
+		t.Fatalf("expected JSON-encoded output, got unparseable line %q: %v", line, err)
+	}
+	if parsed["msg"] != "hello" {
+		t.Fatalf("expected msg field \"hello\", got %v", parsed["msg"])
+	}
+}
+
+func TestNewLoggerFromConfigConsoleEncodingIsNotJSON(t *testing.T) {
+	line := readLoggedLine(t, Config{Encoding: EncodingConsole})
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &parsed); err == nil {
+		t.Fatalf("expected console-encoded output to not parse as JSON, got %q", line)
+	}
+}
+
+func TestLogFormatEnvVarDrivesJSONEncodingDefault(t *testing.T) {
+	t.Setenv(LogFormatEnvVar, "json")
+
+	if got := encodingFromEnv(); got != EncodingJSON {
+		t.Fatalf("expected %s=json to select EncodingJSON, got %q", LogFormatEnvVar, got)
+	}
+
+	// Leave Encoding unset so it falls through to encodingFromEnv, the same
+	// path NewLogger's own default takes.
+	line := readLoggedLine(t, Config{})
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &parsed); err != nil {
+		t.Fatalf("expected %s=json to produce JSON-encoded output, got unparseable line %q: %v", LogFormatEnvVar, line, err)
+	}
+}
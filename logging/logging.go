@@ -3,10 +3,14 @@ package logging
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"sync"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -153,12 +157,32 @@ func (logger Logger) InitializeRequestID(ctx context.Context) (string, context.C
 	ctxLogger := ctx.Value(LoggerKey)
 	if ctxLogger == nil {
 		logger.Debugw("Adding logger to context")
-		ctxLogger = logger.WithRequestID(requestID.(RequestID))
+		ctxLogger = logger.WithRequestID(requestID.(RequestID)).withTraceFields(trace.SpanFromContext(ctx))
 		ctx = context.WithValue(ctx, LoggerKey, ctxLogger)
 	}
 	return requestID.(RequestID).String(), ctx, ctxLogger.(Logger)
 }
 
+// withTraceFields adds trace-id/span-id fields for span whenever its span
+// context is valid, whether or not the span is recording. It is a no-op for a
+// span with an invalid context (e.g. no span was ever started), so callers
+// outside a traced request see no change.
+func (logger Logger) withTraceFields(span trace.Span) Logger {
+	sc := span.SpanContext()
+	if !sc.IsValid() {
+		return logger
+	}
+	combinedValues := logger.appendValueMap(map[string]interface{}{
+		"trace-id": sc.TraceID().String(),
+		"span-id":  sc.SpanID().String(),
+	})
+	return Logger{
+		SugaredLogger: logger.SugaredLogger.With("trace-id", sc.TraceID().String(), "span-id", sc.SpanID().String()),
+		id:            logger.id,
+		Values:        combinedValues,
+	}
+}
+
 func GetRequestIDFromContext(ctx context.Context) string {
 	requestID := ctx.Value(RequestIDKey)
 	if requestID == nil {
@@ -175,7 +199,7 @@ func GetLoggerFromContext(ctx context.Context) Logger {
 		NewLogger("logging").Warn("Logger not found in context")
 		return NewLogger("logger")
 	}
-	return logger.(Logger)
+	return logger.(Logger).withTraceFields(trace.SpanFromContext(ctx))
 }
 
 func (logger Logger) GetRequestID() RequestID {
@@ -202,7 +226,7 @@ func AttachRequestID(id string, ctx context.Context, logger Logger) context.Cont
 		}
 	}
 	ctx = context.WithValue(ctx, RequestIDKey, RequestID(id))
-	logger = logger.WithRequestID(RequestID(id))
+	logger = logger.WithRequestID(RequestID(id)).withTraceFields(trace.SpanFromContext(ctx))
 	ctx = context.WithValue(ctx, LoggerKey, logger)
 	return ctx
 }
@@ -216,6 +240,10 @@ func AddLoggerToContext(ctx context.Context, logger Logger) context.Context {
 }
 
 func NewLogger(service string) Logger {
+	if encodingFromEnv() == EncodingJSON {
+		return NewLoggerFromConfig(service, Config{Encoding: EncodingJSON, Level: zapcore.DebugLevel})
+	}
+
 	baseLogger, err := zap.NewDevelopment(
 		zap.AddStacktrace(zap.WarnLevel),
 	)
@@ -229,6 +257,67 @@ func NewLogger(service string) Logger {
 	}
 }
 
+// WithSpan returns the context's Logger with trace-id/span-id fields
+// attached, and - if ctx carries a recording span - Warn/Error entries are
+// additionally mirrored onto that span as events, so a trace view surfaces
+// the log line without a separate log-export pipeline.
+func WithSpan(ctx context.Context) Logger {
+	logger := GetLoggerFromContext(ctx)
+	span := trace.SpanFromContext(ctx)
+	if !span.SpanContext().IsValid() {
+		return logger
+	}
+	desugared := logger.SugaredLogger.Desugar().WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return spanEventCore{Core: core, span: span}
+	}))
+	logger.SugaredLogger = desugared.Sugar()
+	return logger
+}
+
+// spanEventCore wraps a zapcore.Core so Warn/Error entries are also recorded
+// as events (and, for Error, a span status) on the associated span.
+type spanEventCore struct {
+	zapcore.Core
+	span trace.Span
+}
+
+func (c spanEventCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c spanEventCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ent.Level >= zapcore.WarnLevel {
+		attrs := make([]attribute.KeyValue, 0, len(fields)+1)
+		attrs = append(attrs, attribute.String("level", ent.Level.String()))
+		enc := zapcore.NewMapObjectEncoder()
+		for _, f := range fields {
+			f.AddTo(enc)
+		}
+		for k, v := range enc.Fields {
+			attrs = append(attrs, attribute.String(k, fmtAttr(v)))
+		}
+		c.span.AddEvent(ent.Message, trace.WithAttributes(attrs...))
+		if ent.Level >= zapcore.ErrorLevel {
+			c.span.SetStatus(codes.Error, ent.Message)
+		}
+	}
+	return c.Core.Write(ent, fields)
+}
+
+func (c spanEventCore) With(fields []zapcore.Field) zapcore.Core {
+	return spanEventCore{Core: c.Core.With(fields), span: c.span}
+}
+
+func fmtAttr(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
 func NewStackTraceLogger(service string) Logger {
 	cfg := zap.Config{
 		Encoding:         "json",
@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newObservedLogger() (Logger, *observer.ObservedLogs) {
+	core, observed := observer.New(zapcore.DebugLevel)
+	return Logger{
+		SugaredLogger: zap.New(core).Sugar(),
+		Values:        &sync.Map{},
+	}, observed
+}
+
+func testSpanContext() trace.SpanContext {
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:     trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceFlags: trace.FlagsSampled,
+	})
+}
+
+func TestGetLoggerFromContextAddsTraceFieldsWhenSpanPresent(t *testing.T) {
+	logger, observed := newObservedLogger()
+	ctx := AddLoggerToContext(context.Background(), logger)
+	sc := testSpanContext()
+	ctx = trace.ContextWithSpanContext(ctx, sc)
+
+	GetLoggerFromContext(ctx).Info("hello")
+
+	entries := observed.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 log entry, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["trace-id"] != sc.TraceID().String() {
+		t.Fatalf("expected trace-id %q, got %v", sc.TraceID().String(), fields["trace-id"])
+	}
+	if fields["span-id"] != sc.SpanID().String() {
+		t.Fatalf("expected span-id %q, got %v", sc.SpanID().String(), fields["span-id"])
+	}
+}
+
+func TestGetLoggerFromContextOmitsTraceFieldsWithoutSpan(t *testing.T) {
+	logger, observed := newObservedLogger()
+	ctx := AddLoggerToContext(context.Background(), logger)
+
+	GetLoggerFromContext(ctx).Info("hello")
+
+	entries := observed.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 log entry, got %d", len(entries))
+	}
+	if _, ok := entries[0].ContextMap()["trace-id"]; ok {
+		t.Fatalf("expected no trace-id field without a span in context, got %v", entries[0].ContextMap())
+	}
+}
+
+// recordingSpan wraps a span context with just enough of trace.Span to
+// capture the events and status WithSpan mirrors onto it.
+type recordingSpan struct {
+	trace.Span
+	sc     trace.SpanContext
+	events []string
+	status codes.Code
+}
+
+func (s *recordingSpan) SpanContext() trace.SpanContext { return s.sc }
+
+func (s *recordingSpan) AddEvent(name string, _ ...trace.EventOption) {
+	s.events = append(s.events, name)
+}
+
+func (s *recordingSpan) SetStatus(code codes.Code, _ string) { s.status = code }
+
+func TestWithSpanRecordsEventOnErrorAndWarn(t *testing.T) {
+	logger, observed := newObservedLogger()
+	ctx := AddLoggerToContext(context.Background(), logger)
+	span := &recordingSpan{Span: trace.SpanFromContext(ctx), sc: testSpanContext()}
+	ctx = trace.ContextWithSpan(ctx, span)
+
+	spanLogger := WithSpan(ctx)
+	spanLogger.Info("info entries are not mirrored")
+	spanLogger.Warn("something looked off")
+	spanLogger.Error("something broke")
+
+	entries := observed.All()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 log entries to still reach the underlying core, got %d", len(entries))
+	}
+	if want := []string{"something looked off", "something broke"}; !reflect.DeepEqual(span.events, want) {
+		t.Fatalf("expected span events %v, got %v", want, span.events)
+	}
+	if span.status != codes.Error {
+		t.Fatalf("expected the Error entry to set span status Error, got %v", span.status)
+	}
+}
+
+func TestWithSpanWithoutSpanReturnsPlainLogger(t *testing.T) {
+	logger, observed := newObservedLogger()
+	ctx := AddLoggerToContext(context.Background(), logger)
+
+	WithSpan(ctx).Info("hello")
+
+	entries := observed.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 log entry, got %d", len(entries))
+	}
+	if _, ok := entries[0].ContextMap()["trace-id"]; ok {
+		t.Fatalf("expected no trace-id field without a span in context, got %v", entries[0].ContextMap())
+	}
+}
@@ -0,0 +1,219 @@
+package scheduling
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	statusIndex      TableKey = "/tasks/index/status="
+	triggerTypeIndex TableKey = "/tasks/index/trigger_type="
+	taskDateIndex    TableKey = "/tasks/index/task_id="
+	runIDIndex       TableKey = "/tasks/index/run_id="
+)
+
+func statusIndexKey(status Status, runID TaskRunID) string {
+	return fmt.Sprintf("%s%s/run_id=%d", statusIndex, status, runID)
+}
+
+func triggerTypeIndexKey(triggerType TriggerType, runID TaskRunID) string {
+	return fmt.Sprintf("%s%s/run_id=%d", triggerTypeIndex, triggerType, runID)
+}
+
+func taskDateIndexKey(taskID TaskID, date time.Time, runID TaskRunID) string {
+	return fmt.Sprintf("%s%d/date=%04d%02d%02d/run_id=%d", taskDateIndex, taskID, date.Year(), date.Month(), date.Day(), runID)
+}
+
+// runIDIndexKey keys a run's locator by run ID alone, so a caller that only
+// has a TaskRunID (SetRunStatus and friends) can resolve the owning TaskID
+// without knowing the status, trigger type, or date up front.
+func runIDIndexKey(runID TaskRunID) string {
+	return fmt.Sprintf("%s%d", runIDIndex, runID)
+}
+
+func taskIndexPrefix(taskID TaskID) string {
+	return fmt.Sprintf("%s%d/", taskDateIndex, taskID)
+}
+
+// runLocator is the value stored at every index entry: just enough to go
+// straight to the primary record (GetRunByID needs the task ID to know which
+// /tasks/runs/task_id=<id> list to consult, and the date to find the day's
+// metadata shard) without falling back to a full scan.
+type runLocator struct {
+	RunID  TaskRunID
+	TaskID TaskID
+	Date   time.Time
+}
+
+func (l runLocator) marshal() (string, error) {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal run locator: %v", err)
+	}
+	return string(data), nil
+}
+
+// writeRunIndexes writes the secondary-index entries for a freshly created
+// run alongside its primary record, so QueryRuns never has to fall back to a
+// full scan for status, trigger type, or task+date lookups.
+func (tm *TaskManager) writeRunIndexes(run TaskRunMetadata) error {
+	locator, err := runLocator{RunID: run.ID, TaskID: run.TaskId, Date: run.StartTime}.marshal()
+	if err != nil {
+		return err
+	}
+
+	if err := tm.storage.Set(statusIndexKey(run.Status, run.ID), locator); err != nil {
+		return fmt.Errorf("failed to write status index: %v", err)
+	}
+	if err := tm.storage.Set(triggerTypeIndexKey(run.TriggerType, run.ID), locator); err != nil {
+		return fmt.Errorf("failed to write trigger type index: %v", err)
+	}
+	if err := tm.storage.Set(taskDateIndexKey(run.TaskId, run.StartTime, run.ID), locator); err != nil {
+		return fmt.Errorf("failed to write task/date index: %v", err)
+	}
+	if err := tm.storage.Set(runIDIndexKey(run.ID), locator); err != nil {
+		return fmt.Errorf("failed to write run id index: %v", err)
+	}
+	return nil
+}
+
+// locateRun resolves a TaskRunID to the locator written for it by
+// writeRunIndexes, giving SetRunStatus and friends a TaskID to work with
+// without a full scan.
+func (tm *TaskManager) locateRun(runID TaskRunID) (runLocator, error) {
+	vals, err := tm.storage.Get(runIDIndexKey(runID), false)
+	if err != nil {
+		return runLocator{}, fmt.Errorf("failed to locate run %d: %v", runID, err)
+	}
+	if len(vals) == 0 {
+		return runLocator{}, fmt.Errorf("run not found: %d", runID)
+	}
+	locator := runLocator{}
+	if err := json.Unmarshal([]byte(vals[0]), &locator); err != nil {
+		return runLocator{}, fmt.Errorf("failed to parse index entry: %v", err)
+	}
+	return locator, nil
+}
+
+// updateRunStatusIndex moves the status-index entry for run from oldStatus to
+// run.Status. Without this, a stale entry under oldStatus would make
+// QueryRuns(Status: oldStatus) keep returning a run that has since moved on.
+func (tm *TaskManager) updateRunStatusIndex(run TaskRunMetadata, oldStatus Status) error {
+	if oldStatus == run.Status {
+		return nil
+	}
+	locator, err := runLocator{RunID: run.ID, TaskID: run.TaskId, Date: run.StartTime}.marshal()
+	if err != nil {
+		return err
+	}
+	if _, err := tm.storage.CompareAndDelete(statusIndexKey(oldStatus, run.ID), locator); err != nil {
+		return fmt.Errorf("failed to remove stale status index: %v", err)
+	}
+	return tm.storage.Set(statusIndexKey(run.Status, run.ID), locator)
+}
+
+// RunFilter narrows a QueryRuns call. At least one of Status, TriggerType, or
+// TaskID must be set so the query can resolve an index prefix instead of
+// falling back to a full scan; Start/End additionally bound StartTime and
+// are applied after the indexed candidates are resolved.
+type RunFilter struct {
+	Status      *Status
+	TriggerType *TriggerType
+	TaskID      *TaskID
+	Start       time.Time
+	End         time.Time
+}
+
+// QueryRuns resolves the smallest matching index prefix for each filter
+// field set, intersects the candidate run IDs across them, and only then
+// dereferences the primary record for the survivors.
+func (tm *TaskManager) QueryRuns(filter RunFilter) (TaskRunList, error) {
+	var candidateSets []map[TaskRunID]runLocator
+
+	if filter.Status != nil {
+		set, err := tm.locatorsByPrefix(fmt.Sprintf("%s%s/", statusIndex, *filter.Status))
+		if err != nil {
+			return nil, err
+		}
+		candidateSets = append(candidateSets, set)
+	}
+	if filter.TriggerType != nil {
+		set, err := tm.locatorsByPrefix(fmt.Sprintf("%s%s/", triggerTypeIndex, *filter.TriggerType))
+		if err != nil {
+			return nil, err
+		}
+		candidateSets = append(candidateSets, set)
+	}
+	if filter.TaskID != nil {
+		set, err := tm.locatorsByPrefix(taskIndexPrefix(*filter.TaskID))
+		if err != nil {
+			return nil, err
+		}
+		candidateSets = append(candidateSets, set)
+	}
+	if len(candidateSets) == 0 {
+		return nil, fmt.Errorf("QueryRuns requires at least one of Status, TriggerType, or TaskID")
+	}
+
+	locators := intersectLocatorSets(candidateSets)
+
+	var result TaskRunList
+	for _, locator := range locators {
+		run, err := tm.GetRunByID(locator.TaskID, locator.RunID)
+		if err != nil {
+			return nil, err
+		}
+		if !filter.Start.IsZero() && run.StartTime.Before(filter.Start) {
+			continue
+		}
+		if !filter.End.IsZero() && run.StartTime.After(filter.End) {
+			continue
+		}
+		result = append(result, run)
+	}
+	return result, nil
+}
+
+func (tm *TaskManager) locatorsByPrefix(prefix string) (map[TaskRunID]runLocator, error) {
+	values, err := tm.storage.Get(prefix, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan index prefix %s: %v", prefix, err)
+	}
+	set := make(map[TaskRunID]runLocator, len(values))
+	for _, value := range values {
+		locator := runLocator{}
+		if err := json.Unmarshal([]byte(value), &locator); err != nil {
+			return nil, fmt.Errorf("failed to parse index entry: %v", err)
+		}
+		set[locator.RunID] = locator
+	}
+	return set, nil
+}
+
+// intersectLocatorSets returns the locators present in every set, picking
+// the smallest set as the driver so the work scales with the most selective
+// filter rather than the least.
+func intersectLocatorSets(sets []map[TaskRunID]runLocator) []runLocator {
+	smallest := sets[0]
+	for _, set := range sets[1:] {
+		if len(set) < len(smallest) {
+			smallest = set
+		}
+	}
+
+	var result []runLocator
+	for runID, locator := range smallest {
+		inAll := true
+		for _, set := range sets {
+			if _, ok := set[runID]; !ok {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			result = append(result, locator)
+		}
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package scheduling
+
+import "testing"
+
+const benchmarkRunCount = 10000
+
+func setupQueryBenchmarkData(b *testing.B, n int) TaskManager {
+	storage := newMemoryStorage()
+	tm := NewTaskManager(storage)
+	task, err := tm.CreateTask("bench-task", ResourceCreation, Provider{Name: "bench"})
+	if err != nil {
+		b.Fatalf("failed to create task: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		trigger := OneOffTrigger{TriggerName: "bench", TriggerType: oneOffTrigger}
+		if _, err := tm.CreateTaskRun("bench-run", task.ID, trigger); err != nil {
+			b.Fatalf("failed to create task run: %v", err)
+		}
+	}
+	return tm
+}
+
+// BenchmarkQueryRunsByStatusIndexed exercises the new index-backed path: one
+// prefix scan over /tasks/index/status=PENDING plus one GetRunByID per hit.
+func BenchmarkQueryRunsByStatusIndexed(b *testing.B) {
+	tm := setupQueryBenchmarkData(b, benchmarkRunCount)
+	status := Pending
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tm.QueryRuns(RunFilter{Status: &status}); err != nil {
+			b.Fatalf("QueryRuns failed: %v", err)
+		}
+	}
+}
+
+// BenchmarkQueryRunsByStatusFullScan is the pre-index baseline: load every
+// run and filter in memory, as TaskRunList.FilterByStatus already did.
+func BenchmarkQueryRunsByStatusFullScan(b *testing.B) {
+	tm := setupQueryBenchmarkData(b, benchmarkRunCount)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runs, err := tm.GetAllTaskRuns()
+		if err != nil {
+			b.Fatalf("GetAllTaskRuns failed: %v", err)
+		}
+		runs.FilterByStatus(Pending)
+	}
+}
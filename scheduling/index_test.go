@@ -0,0 +1,93 @@
+package scheduling
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestTaskManager(t *testing.T) TaskManager {
+	t.Helper()
+	return NewTaskManager(newMemoryStorage())
+}
+
+func TestQueryRunsByStatus(t *testing.T) {
+	tm := newTestTaskManager(t)
+	task, err := tm.CreateTask("task", ResourceCreation, Provider{Name: "p"})
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+
+	run, err := tm.CreateTaskRun("run", task.ID, OneOffTrigger{TriggerName: "t", TriggerType: oneOffTrigger})
+	if err != nil {
+		t.Fatalf("failed to create task run: %v", err)
+	}
+
+	status := Pending
+	results, err := tm.QueryRuns(RunFilter{Status: &status})
+	if err != nil {
+		t.Fatalf("QueryRuns failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != run.ID {
+		t.Fatalf("expected exactly run %d, got %+v", run.ID, results)
+	}
+
+	missing := Failed
+	results, err = tm.QueryRuns(RunFilter{Status: &missing})
+	if err != nil {
+		t.Fatalf("QueryRuns failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("expected no failed runs, got %+v", results)
+	}
+}
+
+func TestQueryRunsIntersectsFilters(t *testing.T) {
+	tm := newTestTaskManager(t)
+	taskA, err := tm.CreateTask("a", ResourceCreation, Provider{Name: "a"})
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	taskB, err := tm.CreateTask("b", ResourceCreation, Provider{Name: "b"})
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+
+	runA, err := tm.CreateTaskRun("run", taskA.ID, OneOffTrigger{TriggerName: "t", TriggerType: oneOffTrigger})
+	if err != nil {
+		t.Fatalf("failed to create task run: %v", err)
+	}
+	if _, err := tm.CreateTaskRun("run", taskB.ID, OneOffTrigger{TriggerName: "t", TriggerType: oneOffTrigger}); err != nil {
+		t.Fatalf("failed to create task run: %v", err)
+	}
+
+	status := Pending
+	results, err := tm.QueryRuns(RunFilter{Status: &status, TaskID: &taskA.ID})
+	if err != nil {
+		t.Fatalf("QueryRuns failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != runA.ID {
+		t.Fatalf("expected exactly run %d for task %d, got %+v", runA.ID, taskA.ID, results)
+	}
+}
+
+func TestGetRunsByDateReturnsRunsWithinWindow(t *testing.T) {
+	tm := newTestTaskManager(t)
+	task, err := tm.CreateTask("task", ResourceCreation, Provider{Name: "p"})
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	run, err := tm.CreateTaskRun("run", task.ID, OneOffTrigger{TriggerName: "t", TriggerType: oneOffTrigger})
+	if err != nil {
+		t.Fatalf("failed to create task run: %v", err)
+	}
+
+	start := run.StartTime.Add(-time.Hour)
+	end := run.StartTime.Add(time.Hour)
+	results, err := tm.GetRunsByDate(start, end)
+	if err != nil {
+		t.Fatalf("GetRunsByDate failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != run.ID {
+		t.Fatalf("expected GetRunsByDate to return the run created within the window, got %+v", results)
+	}
+}
@@ -0,0 +1,224 @@
+package scheduling
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	TASKLOCKS TableKey = "/tasks/locks/run_id="
+
+	defaultLockTTL = 15 * time.Second
+)
+
+func (tk TableKey) GetTaskLockKey(id TaskRunID) string {
+	return fmt.Sprintf("%s%d", tk, id)
+}
+
+// lockRecord is the value stored at a lock key. OwnerID identifies the
+// TaskManager instance that holds the lease; AcquiredAt/LeaseTTL together
+// determine when the lease is considered abandoned and reclaimable.
+type lockRecord struct {
+	OwnerID    string        `json:"ownerId"`
+	AcquiredAt time.Time     `json:"acquiredAt"`
+	LeaseTTL   time.Duration `json:"leaseTtl"`
+}
+
+func (r lockRecord) expired(now time.Time) bool {
+	return now.After(r.AcquiredAt.Add(r.LeaseTTL))
+}
+
+func (r lockRecord) marshal() (string, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal lock record: %v", err)
+	}
+	return string(data), nil
+}
+
+// heldLock is this process's bookkeeping for a lease it currently owns.
+type heldLock struct {
+	record lockRecord
+	raw    string
+	cancel context.CancelFunc
+}
+
+// LockTaskRun acquires a distributed, TTL-based lease on runId so that only
+// one TaskManager, across any number of worker processes sharing the same
+// StorageProvider, can hold it at a time. The lease is refreshed in the
+// background at LeaseTTL/3 until UnlockTaskRun is called or ctx is
+// cancelled. UnlockTaskRun stops the refresher and deletes the lock record;
+// on ctx cancellation the refresher simply stops and the lease expires on
+// its own, so a crashed holder's lock is always reclaimable once the TTL
+// passes.
+func (tm *TaskManager) LockTaskRun(ctx context.Context, runId TaskRunID) error {
+	key := TASKLOCKS.GetTaskLockKey(runId)
+	record := lockRecord{OwnerID: tm.ownerID, AcquiredAt: time.Now().UTC(), LeaseTTL: defaultLockTTL}
+	raw, err := record.marshal()
+	if err != nil {
+		return err
+	}
+
+	acquired, err := tm.storage.SetIfNotExists(key, raw)
+	if err != nil {
+		return fmt.Errorf("failed to acquire lock for run %d: %v", runId, err)
+	}
+	if !acquired {
+		if err := tm.reclaimExpiredLock(key); err != nil {
+			return err
+		}
+		acquired, err = tm.storage.SetIfNotExists(key, raw)
+		if err != nil {
+			return fmt.Errorf("failed to acquire lock for run %d: %v", runId, err)
+		}
+		if !acquired {
+			return fmt.Errorf("run %d is locked by another owner", runId)
+		}
+	}
+
+	lockCtx, cancel := context.WithCancel(ctx)
+	tm.locks.mu.Lock()
+	tm.locks.held[runId] = &heldLock{record: record, raw: raw, cancel: cancel}
+	tm.locks.mu.Unlock()
+
+	go tm.refreshLock(lockCtx, runId, key)
+	return nil
+}
+
+// reclaimExpiredLock deletes key if, and only if, the lock record currently
+// there has passed its TTL. It leaves a live lock untouched.
+func (tm *TaskManager) reclaimExpiredLock(key string) error {
+	vals, err := tm.storage.Get(key, false)
+	if err != nil || len(vals) == 0 {
+		// StorageProvider implementations commonly surface a missing key as
+		// an error rather than an empty, error-free result, so treat either
+		// the same way: we raced with the holder releasing it, and the
+		// caller's SetIfNotExists retry will settle who gets it.
+		return nil
+	}
+
+	existing := lockRecord{}
+	if err := json.Unmarshal([]byte(vals[0]), &existing); err != nil {
+		return fmt.Errorf("failed to parse lock record: %v", err)
+	}
+	if !existing.expired(time.Now().UTC()) {
+		return nil
+	}
+
+	if _, err := tm.storage.CompareAndDelete(key, vals[0]); err != nil {
+		return fmt.Errorf("failed to reclaim expired lock for key %s: %v", key, err)
+	}
+	return nil
+}
+
+func (tm *TaskManager) refreshLock(ctx context.Context, runId TaskRunID, key string) {
+	ticker := time.NewTicker(defaultLockTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !tm.refreshOnce(runId, key) {
+				return
+			}
+		}
+	}
+}
+
+// refreshOnce performs a single refresh tick, returning false once the
+// refresher should stop (the lock was released locally, or lost to another
+// owner and dropped).
+func (tm *TaskManager) refreshOnce(runId TaskRunID, key string) bool {
+	tm.locks.mu.Lock()
+	held, ok := tm.locks.held[runId]
+	tm.locks.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	refreshed := held.record
+	refreshed.AcquiredAt = time.Now().UTC()
+	raw, err := refreshed.marshal()
+	if err != nil {
+		return true
+	}
+
+	// A single CompareAndSwap refreshes the TTL atomically - unlike a delete
+	// then recreate, there is no window in which another TaskManager can
+	// take the now-empty key and this refresher's SetIfNotExists returns
+	// ok=false but no error, silently leaving us believing we still hold a
+	// lease someone else now owns.
+	swapped, err := tm.storage.CompareAndSwap(key, held.raw, raw)
+	if err != nil {
+		return true
+	}
+	if !swapped {
+		// Someone else holds this key now; the lease is gone, so stop
+		// refreshing and drop it instead of leaving stale bookkeeping that
+		// would make requireLock keep passing.
+		tm.dropHeldLock(runId)
+		return false
+	}
+
+	tm.locks.mu.Lock()
+	if held, ok := tm.locks.held[runId]; ok {
+		held.record = refreshed
+		held.raw = raw
+	}
+	tm.locks.mu.Unlock()
+	return true
+}
+
+// dropHeldLock removes runId's bookkeeping and cancels its refresher. Used
+// when the stored lock record no longer matches what this process believes
+// it holds, e.g. refreshLock lost a CompareAndSwap race to another owner.
+func (tm *TaskManager) dropHeldLock(runId TaskRunID) {
+	tm.locks.mu.Lock()
+	held, ok := tm.locks.held[runId]
+	if ok {
+		delete(tm.locks.held, runId)
+	}
+	tm.locks.mu.Unlock()
+	if ok {
+		held.cancel()
+	}
+}
+
+// UnlockTaskRun releases a lease previously acquired by this TaskManager.
+func (tm *TaskManager) UnlockTaskRun(ctx context.Context, runId TaskRunID) error {
+	tm.locks.mu.Lock()
+	held, ok := tm.locks.held[runId]
+	if ok {
+		delete(tm.locks.held, runId)
+	}
+	tm.locks.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("run %d is not locked by this task manager", runId)
+	}
+	held.cancel()
+
+	key := TASKLOCKS.GetTaskLockKey(runId)
+	if _, err := tm.storage.CompareAndDelete(key, held.raw); err != nil {
+		return fmt.Errorf("failed to release lock for run %d: %v", runId, err)
+	}
+	return nil
+}
+
+// requireLock is the guard SetRunStatus and friends use before writing: a
+// caller must hold a live, unexpired lease on runId.
+func (tm *TaskManager) requireLock(runId TaskRunID) error {
+	tm.locks.mu.Lock()
+	held, ok := tm.locks.held[runId]
+	tm.locks.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("run %d: caller does not hold the lock", runId)
+	}
+	if held.record.expired(time.Now().UTC()) {
+		return fmt.Errorf("run %d: lock has expired", runId)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package scheduling
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// BackoffStrategy selects how RetryPolicy.nextDelay grows the delay between
+// attempts.
+type BackoffStrategy string
+
+const (
+	FixedBackoff             BackoffStrategy = "Fixed"
+	ExponentialBackoff       BackoffStrategy = "Exponential"
+	ExponentialJitterBackoff BackoffStrategy = "ExponentialJitter"
+)
+
+// RetryPolicy governs how SetRunStatus responds when a run finishes Failed.
+// A nil *RetryPolicy on TaskMetadata leaves failed runs terminal, matching
+// the pre-retry behavior.
+type RetryPolicy struct {
+	MaxAttempts     int
+	BackoffStrategy BackoffStrategy
+	InitialDelay    time.Duration
+	MaxDelay        time.Duration
+}
+
+// nextDelay computes the backoff before retrying a run that just finished
+// its nth attempt (attempt is 1 for the very first run), as
+// min(MaxDelay, InitialDelay * 2^(attempt-1)) for the exponential
+// strategies, then optionally applies full jitter: a uniform draw in
+// [0, delay) so a burst of simultaneous failures doesn't retry in lockstep.
+func (p RetryPolicy) nextDelay(attempt int) time.Duration {
+	var delay time.Duration
+	switch p.BackoffStrategy {
+	case FixedBackoff:
+		delay = p.InitialDelay
+	case ExponentialBackoff, ExponentialJitterBackoff:
+		delay = p.InitialDelay * time.Duration(int64(1)<<uint(attempt-1))
+	default:
+		delay = p.InitialDelay
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.BackoffStrategy == ExponentialJitterBackoff && delay > 0 {
+		delay = time.Duration(rand.Int63n(int64(delay)))
+	}
+	return delay
+}
+
+const deadLetterIndex TableKey = "/tasks/deadletter/run_id="
+
+func deadLetterKey(runID TaskRunID) string {
+	return fmt.Sprintf("%s%d", deadLetterIndex, runID)
+}
+
+// handleFailedRun is called by SetRunStatus once a run has been written as
+// Failed. It consults the owning task's RetryPolicy: if attempts remain, it
+// schedules a retry linked back to run via ParentRunID; otherwise run is
+// written to the dead-letter index for operator triage.
+func (tm *TaskManager) handleFailedRun(run TaskRunMetadata) error {
+	task, err := tm.GetTaskByID(run.TaskId)
+	if err != nil {
+		return err
+	}
+	if task.RetryPolicy == nil {
+		return nil
+	}
+
+	attempt, err := tm.attemptNumber(run)
+	if err != nil {
+		return err
+	}
+	if attempt >= task.RetryPolicy.MaxAttempts {
+		return tm.writeDeadLetter(run)
+	}
+
+	delay := task.RetryPolicy.nextDelay(attempt)
+	parentID := run.ID
+	_, err = tm.createRun(run.Name, run.TaskId, OneOffTrigger{TriggerName: run.Name, TriggerType: oneOffTrigger}, time.Now().UTC().Add(delay), &parentID)
+	if err != nil {
+		return fmt.Errorf("failed to schedule retry for run %d: %v", run.ID, err)
+	}
+	return nil
+}
+
+// attemptNumber counts how many runs precede run in its ParentRunID chain,
+// including run itself, so the first attempt reports 1.
+func (tm *TaskManager) attemptNumber(run TaskRunMetadata) (int, error) {
+	attempt := 1
+	for run.ParentRunID != nil {
+		parent, err := tm.GetRunByID(run.TaskId, *run.ParentRunID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to walk retry chain at run %d: %v", *run.ParentRunID, err)
+		}
+		attempt++
+		run = parent
+	}
+	return attempt, nil
+}
+
+// writeDeadLetter records a run that has exhausted its RetryPolicy so it
+// shows up in ListDeadLetter instead of silently staying Failed.
+func (tm *TaskManager) writeDeadLetter(run TaskRunMetadata) error {
+	locator, err := runLocator{RunID: run.ID, TaskID: run.TaskId, Date: run.StartTime}.marshal()
+	if err != nil {
+		return err
+	}
+	if err := tm.storage.Set(deadLetterKey(run.ID), locator); err != nil {
+		return fmt.Errorf("failed to write dead-letter entry: %v", err)
+	}
+	return nil
+}
+
+// ListDeadLetter returns every run that exhausted its RetryPolicy.
+func (tm *TaskManager) ListDeadLetter() (TaskRunList, error) {
+	values, err := tm.storage.Get(deadLetterIndex.ToString(), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan dead-letter index: %v", err)
+	}
+
+	var runs TaskRunList
+	for _, value := range values {
+		locator := runLocator{}
+		if err := json.Unmarshal([]byte(value), &locator); err != nil {
+			return nil, fmt.Errorf("failed to parse dead-letter entry: %v", err)
+		}
+		run, err := tm.GetRunByID(locator.TaskID, locator.RunID)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// RequeueDeadLetter removes runID from the dead-letter index and schedules a
+// fresh attempt for it, picking up where the exhausted retry chain left off.
+func (tm *TaskManager) RequeueDeadLetter(runID TaskRunID) (TaskRunMetadata, error) {
+	vals, err := tm.storage.Get(deadLetterKey(runID), false)
+	if err != nil || len(vals) == 0 {
+		return TaskRunMetadata{}, fmt.Errorf("run not found in dead-letter index: %d", runID)
+	}
+
+	locator, err := tm.locateRun(runID)
+	if err != nil {
+		return TaskRunMetadata{}, err
+	}
+	run, err := tm.GetRunByID(locator.TaskID, locator.RunID)
+	if err != nil {
+		return TaskRunMetadata{}, err
+	}
+
+	if _, err := tm.storage.CompareAndDelete(deadLetterKey(runID), vals[0]); err != nil {
+		return TaskRunMetadata{}, fmt.Errorf("failed to clear dead-letter entry: %v", err)
+	}
+
+	parentID := run.ID
+	return tm.createRun(run.Name, run.TaskId, OneOffTrigger{TriggerName: run.Name, TriggerType: oneOffTrigger}, time.Now().UTC(), &parentID)
+}
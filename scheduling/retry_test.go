@@ -0,0 +1,190 @@
+package scheduling
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyNextDelayExponentialGrowth(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:     10,
+		BackoffStrategy: ExponentialBackoff,
+		InitialDelay:    time.Second,
+		MaxDelay:        time.Hour,
+	}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}
+	for attempt, want := range expected {
+		got := policy.nextDelay(attempt + 1)
+		if got != want {
+			t.Fatalf("attempt %d: expected %s, got %s", attempt+1, want, got)
+		}
+	}
+}
+
+func TestRetryPolicyNextDelayCapsAtMaxDelay(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:     20,
+		BackoffStrategy: ExponentialBackoff,
+		InitialDelay:    time.Second,
+		MaxDelay:        10 * time.Second,
+	}
+
+	got := policy.nextDelay(10)
+	if got != 10*time.Second {
+		t.Fatalf("expected delay capped at MaxDelay (10s), got %s", got)
+	}
+}
+
+func TestRetryPolicyNextDelayJitterBounds(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:     10,
+		BackoffStrategy: ExponentialJitterBackoff,
+		InitialDelay:    time.Second,
+		MaxDelay:        time.Minute,
+	}
+
+	for i := 0; i < 100; i++ {
+		got := policy.nextDelay(5)
+		if got < 0 || got >= policy.MaxDelay {
+			t.Fatalf("jittered delay %s out of bounds [0, %s)", got, policy.MaxDelay)
+		}
+	}
+}
+
+func TestRetryPolicyNextDelayFixed(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:     5,
+		BackoffStrategy: FixedBackoff,
+		InitialDelay:    3 * time.Second,
+		MaxDelay:        time.Minute,
+	}
+
+	for attempt := 1; attempt <= 4; attempt++ {
+		if got := policy.nextDelay(attempt); got != 3*time.Second {
+			t.Fatalf("attempt %d: expected fixed delay 3s, got %s", attempt, got)
+		}
+	}
+}
+
+func TestSetRunStatusFailedSchedulesRetryWithParentChain(t *testing.T) {
+	storage := newMemoryStorage()
+	tm := NewTaskManager(storage)
+
+	task, err := tm.CreateTask("retryable", ResourceCreation, Provider{Name: "p"})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	task.RetryPolicy = &RetryPolicy{
+		MaxAttempts:     3,
+		BackoffStrategy: FixedBackoff,
+		InitialDelay:    time.Millisecond,
+		MaxDelay:        time.Second,
+	}
+	if err := tm.storage.Set(TASKMETADATA.GetTaskMetadataKey(task.ID), marshalTask(t, task)); err != nil {
+		t.Fatalf("failed to persist retry policy: %v", err)
+	}
+
+	run, err := tm.CreateTaskRun("run", task.ID, OneOffTrigger{TriggerName: "run", TriggerType: oneOffTrigger})
+	if err != nil {
+		t.Fatalf("CreateTaskRun failed: %v", err)
+	}
+
+	if err := tm.LockTaskRun(context.Background(), run.ID); err != nil {
+		t.Fatalf("LockTaskRun failed: %v", err)
+	}
+	if err := tm.SetRunStatus(run.ID, Failed, fmt.Errorf("boom")); err != nil {
+		t.Fatalf("SetRunStatus failed: %v", err)
+	}
+
+	runs, err := tm.QueryRuns(RunFilter{TaskID: &task.ID})
+	if err != nil {
+		t.Fatalf("QueryRuns failed: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("expected original run plus one retry, got %d runs", len(runs))
+	}
+
+	var retry *TaskRunMetadata
+	for i := range runs {
+		if runs[i].ParentRunID != nil && *runs[i].ParentRunID == run.ID {
+			retry = &runs[i]
+		}
+	}
+	if retry == nil {
+		t.Fatalf("expected a retry run with ParentRunID %d, got %+v", run.ID, runs)
+	}
+
+	attempt, err := tm.attemptNumber(*retry)
+	if err != nil {
+		t.Fatalf("attemptNumber failed: %v", err)
+	}
+	if attempt != 2 {
+		t.Fatalf("expected retry to be attempt 2, got %d", attempt)
+	}
+}
+
+func TestSetRunStatusFailedExhaustsToDeadLetter(t *testing.T) {
+	storage := newMemoryStorage()
+	tm := NewTaskManager(storage)
+
+	task, err := tm.CreateTask("retryable", ResourceCreation, Provider{Name: "p"})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	task.RetryPolicy = &RetryPolicy{
+		MaxAttempts:     1,
+		BackoffStrategy: FixedBackoff,
+		InitialDelay:    time.Millisecond,
+		MaxDelay:        time.Second,
+	}
+	if err := tm.storage.Set(TASKMETADATA.GetTaskMetadataKey(task.ID), marshalTask(t, task)); err != nil {
+		t.Fatalf("failed to persist retry policy: %v", err)
+	}
+
+	run, err := tm.CreateTaskRun("run", task.ID, OneOffTrigger{TriggerName: "run", TriggerType: oneOffTrigger})
+	if err != nil {
+		t.Fatalf("CreateTaskRun failed: %v", err)
+	}
+	if err := tm.LockTaskRun(context.Background(), run.ID); err != nil {
+		t.Fatalf("LockTaskRun failed: %v", err)
+	}
+	if err := tm.SetRunStatus(run.ID, Failed, fmt.Errorf("boom")); err != nil {
+		t.Fatalf("SetRunStatus failed: %v", err)
+	}
+
+	deadLetters, err := tm.ListDeadLetter()
+	if err != nil {
+		t.Fatalf("ListDeadLetter failed: %v", err)
+	}
+	if len(deadLetters) != 1 || deadLetters[0].ID != run.ID {
+		t.Fatalf("expected run %d in dead-letter index, got %+v", run.ID, deadLetters)
+	}
+
+	requeued, err := tm.RequeueDeadLetter(run.ID)
+	if err != nil {
+		t.Fatalf("RequeueDeadLetter failed: %v", err)
+	}
+	if requeued.ParentRunID == nil || *requeued.ParentRunID != run.ID {
+		t.Fatalf("expected requeued run to link back to %d, got %+v", run.ID, requeued.ParentRunID)
+	}
+
+	deadLetters, err = tm.ListDeadLetter()
+	if err != nil {
+		t.Fatalf("ListDeadLetter failed: %v", err)
+	}
+	if len(deadLetters) != 0 {
+		t.Fatalf("expected dead-letter index to be empty after requeue, got %+v", deadLetters)
+	}
+}
+
+func marshalTask(t *testing.T, task TaskMetadata) string {
+	t.Helper()
+	data, err := task.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal task: %v", err)
+	}
+	return string(data)
+}
@@ -0,0 +1,232 @@
+package scheduling
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Scheduler loads the tasks with a RecurringTrigger schedule from
+// TASKMETADATA and calls TaskManager.CreateTaskRun when one is due. Due
+// triggers are tracked in a min-heap keyed by NextRunAt so a tick only has to
+// look at the heap's root instead of rescanning every task. TASKMETADATA is
+// read only in Start: a task created or rescheduled after Start is not picked
+// up by a running Scheduler.
+type Scheduler struct {
+	taskManager  TaskManager
+	pollInterval time.Duration
+
+	mu    sync.Mutex
+	queue schedulerQueue
+
+	cancel context.CancelFunc
+}
+
+func NewScheduler(taskManager TaskManager) *Scheduler {
+	return &Scheduler{
+		taskManager:  taskManager,
+		pollInterval: time.Second,
+	}
+}
+
+// Start loads every recurring task's schedule and begins polling in a
+// background goroutine. The returned error only reflects the initial load;
+// a failed fire is not fatal: tick retries a due entry up to
+// maxFireAttemptsPerTick times, then advances it to its next slot without
+// firing.
+func (s *Scheduler) Start(ctx context.Context) error {
+	if err := s.loadSchedule(); err != nil {
+		return fmt.Errorf("failed to load schedule: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+	go s.run(ctx)
+	return nil
+}
+
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// loadSchedule populates the heap from TASKMETADATA. A recurring trigger
+// found overdue at load time (the process was down past its NextRunAt) is
+// resolved per its MisfirePolicy: SkipMisfire jumps straight to the next
+// future slot, FireImmediately is left as-is so the next tick fires it once
+// before resuming the normal cadence.
+func (s *Scheduler) loadSchedule() error {
+	tasks, err := s.taskManager.GetAllTasks()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	q := schedulerQueue{}
+	heap.Init(&q)
+	for _, task := range tasks {
+		recurring, ok := task.Schedule.(RecurringTrigger)
+		if !ok {
+			continue
+		}
+		if recurring.GetNextRunAt().Before(now) && recurring.GetMisfirePolicy() == SkipMisfire {
+			recurring, err = recurring.CatchUp(now)
+			if err != nil {
+				return fmt.Errorf("failed to catch up schedule for task %d: %v", task.ID, err)
+			}
+			if err := s.taskManager.UpdateTaskSchedule(task.ID, recurring); err != nil {
+				return err
+			}
+		}
+		heap.Push(&q, &schedulerEntry{taskID: task.ID, trigger: recurring})
+	}
+
+	s.mu.Lock()
+	s.queue = q
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.tick()
+		}
+	}
+}
+
+// maxFireAttemptsPerTick bounds how many times a single tick will retry the
+// same due entry. Without this, an entry whose CreateTaskRun keeps failing
+// (e.g. storage is down) never advances past its due NextRunAt, so popDue
+// keeps handing it straight back and tick never returns - wedging the
+// scheduler's one goroutine and starving every other task.
+const maxFireAttemptsPerTick = 3
+
+func (s *Scheduler) tick() {
+	now := time.Now().UTC()
+	attempts := map[TaskID]int{}
+	for {
+		entry := s.popDue(now)
+		if entry == nil {
+			return
+		}
+		attempts[entry.taskID]++
+		if attempts[entry.taskID] > maxFireAttemptsPerTick {
+			s.skipEntry(entry)
+			continue
+		}
+		s.fire(entry)
+	}
+}
+
+// skipEntry force-advances entry past its current due slot without calling
+// CreateTaskRun. It's used once an entry has exhausted its retries for this
+// tick, so a persistently broken task falls back to its next scheduled slot
+// instead of keeping the scheduler busy forever.
+//
+// If Advance itself fails (e.g. a stored CronTrigger with a now-invalid cron
+// expression or timezone), entry's NextRunAt can't move forward at all, so
+// re-queuing it unchanged would leave it due forever and wedge tick just
+// like the CreateTaskRun failure maxFireAttemptsPerTick guards against.
+// Drop it instead: it stops firing until the schedule is fixed and reloaded,
+// which is strictly better than taking every other task down with it.
+func (s *Scheduler) skipEntry(entry *schedulerEntry) {
+	advanced, err := entry.trigger.Advance()
+	if err != nil {
+		return
+	}
+	entry.trigger = advanced
+	_ = s.taskManager.UpdateTaskSchedule(entry.taskID, advanced)
+
+	s.mu.Lock()
+	heap.Push(&s.queue, entry)
+	s.mu.Unlock()
+}
+
+func (s *Scheduler) popDue(now time.Time) *schedulerEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.queue.Len() == 0 {
+		return nil
+	}
+	if s.queue[0].trigger.GetNextRunAt().After(now) {
+		return nil
+	}
+	return heap.Pop(&s.queue).(*schedulerEntry)
+}
+
+func (s *Scheduler) fire(entry *schedulerEntry) {
+	defer func() {
+		s.mu.Lock()
+		heap.Push(&s.queue, entry)
+		s.mu.Unlock()
+	}()
+
+	if _, err := s.taskManager.CreateTaskRun(string(entry.trigger.Type()), entry.taskID, entry.trigger); err != nil {
+		return
+	}
+
+	advanced, err := entry.trigger.Advance()
+	if err != nil {
+		return
+	}
+	// An overdue trigger fires once for all of its missed slots, then
+	// resumes from the next slot after now.
+	if now := time.Now().UTC(); !advanced.GetNextRunAt().After(now) {
+		advanced, err = advanced.CatchUp(now)
+		if err != nil {
+			return
+		}
+	}
+	entry.trigger = advanced
+
+	// Best-effort: if this fails the in-memory NextRunAt is still correct
+	// for this process, it just won't survive a restart.
+	_ = s.taskManager.UpdateTaskSchedule(entry.taskID, advanced)
+}
+
+type schedulerEntry struct {
+	taskID  TaskID
+	trigger RecurringTrigger
+	index   int
+}
+
+// schedulerQueue is a container/heap.Interface min-heap ordered by the
+// trigger's NextRunAt, so the earliest-due entry is always at the root.
+type schedulerQueue []*schedulerEntry
+
+func (q schedulerQueue) Len() int { return len(q) }
+
+func (q schedulerQueue) Less(i, j int) bool {
+	return q[i].trigger.GetNextRunAt().Before(q[j].trigger.GetNextRunAt())
+}
+
+func (q schedulerQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *schedulerQueue) Push(x interface{}) {
+	entry := x.(*schedulerEntry)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *schedulerQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.index = -1
+	*q = old[:n-1]
+	return entry
+}
@@ -0,0 +1,195 @@
+package scheduling
+
+import (
+	"container/heap"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCronTriggerParsing(t *testing.T) {
+	testCases := []struct {
+		name       string
+		cronExpr   string
+		timezone   string
+		expectFail bool
+	}{
+		{name: "EveryMinute", cronExpr: "* * * * *", timezone: "UTC"},
+		{name: "DailyAtMidnight", cronExpr: "0 0 * * *", timezone: "America/New_York"},
+		{name: "TooFewFields", cronExpr: "* * *", timezone: "UTC", expectFail: true},
+		{name: "BadTimezone", cronExpr: "* * * * *", timezone: "Not/AZone", expectFail: true},
+	}
+
+	for _, currTest := range testCases {
+		t.Run(currTest.name, func(t *testing.T) {
+			_, err := NewCronTrigger("trigger", currTest.cronExpr, currTest.timezone, FireImmediately)
+			if currTest.expectFail && err == nil {
+				t.Fatalf("expected an error for %s/%s but got none", currTest.cronExpr, currTest.timezone)
+			}
+			if !currTest.expectFail && err != nil {
+				t.Fatalf("unexpected error for %s/%s: %v", currTest.cronExpr, currTest.timezone, err)
+			}
+		})
+	}
+}
+
+func TestCronTriggerDSTTransition(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("tzdata not available: %v", err)
+	}
+
+	// 2024-03-10 is the US spring-forward date: 2:00 AM becomes 3:00 AM.
+	trigger := CronTrigger{
+		TriggerName:    "dst",
+		TriggerType:    cronTrigger,
+		CronExpression: "30 2 * * *",
+		Timezone:       "America/New_York",
+		NextRunAt:      time.Date(2024, 3, 9, 2, 30, 0, 0, loc),
+		Misfire:        FireImmediately,
+	}
+
+	advanced, err := trigger.Advance()
+	if err != nil {
+		t.Fatalf("failed to advance trigger: %v", err)
+	}
+
+	next := advanced.GetNextRunAt()
+	if next.In(loc).Day() != 11 {
+		t.Fatalf("expected cron to skip the missing 2:30 AM on the spring-forward day, got %v", next.In(loc))
+	}
+}
+
+func TestCronTriggerCatchUp(t *testing.T) {
+	trigger := CronTrigger{
+		TriggerName:    "catchup",
+		TriggerType:    cronTrigger,
+		CronExpression: "0 * * * *",
+		Timezone:       "UTC",
+		NextRunAt:      time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Misfire:        SkipMisfire,
+	}
+
+	// Simulate the process coming back up three hours late.
+	now := time.Date(2026, 1, 1, 3, 15, 0, 0, time.UTC)
+	caught, err := trigger.CatchUp(now)
+	if err != nil {
+		t.Fatalf("failed to catch up trigger: %v", err)
+	}
+
+	want := time.Date(2026, 1, 1, 4, 0, 0, 0, time.UTC)
+	if !caught.GetNextRunAt().Equal(want) {
+		t.Fatalf("expected catch-up to jump to %v, got %v", want, caught.GetNextRunAt())
+	}
+}
+
+func TestIntervalTriggerAdvance(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	trigger := IntervalTrigger{
+		TriggerName: "interval",
+		TriggerType: intervalTrigger,
+		Interval:    5 * time.Minute,
+		NextRunAt:   start,
+		Misfire:     FireImmediately,
+	}
+
+	advanced, err := trigger.Advance()
+	if err != nil {
+		t.Fatalf("failed to advance trigger: %v", err)
+	}
+	if !advanced.GetNextRunAt().Equal(start.Add(5 * time.Minute)) {
+		t.Fatalf("expected next run at %v, got %v", start.Add(5*time.Minute), advanced.GetNextRunAt())
+	}
+}
+
+// TestTickDoesNotWedgeOnPersistentFailure reproduces a due entry whose
+// CreateTaskRun fails every time (a corrupted run-list record). tick must
+// still return - a broken task backs off to its next slot instead of
+// keeping the scheduler's single goroutine spinning forever.
+func TestTickDoesNotWedgeOnPersistentFailure(t *testing.T) {
+	storage := newMemoryStorage()
+	tm := NewTaskManager(storage)
+
+	task, err := tm.CreateTask("broken-task", ResourceCreation, Provider{Name: "p"})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if err := storage.Set(fmt.Sprintf("/tasks/runs/task_id=%d", task.ID), "not valid json"); err != nil {
+		t.Fatalf("failed to corrupt run list: %v", err)
+	}
+
+	trigger := IntervalTrigger{
+		TriggerName: "broken",
+		TriggerType: intervalTrigger,
+		Interval:    time.Minute,
+		NextRunAt:   time.Now().UTC().Add(-time.Second),
+		Misfire:     FireImmediately,
+	}
+
+	scheduler := NewScheduler(tm)
+	scheduler.mu.Lock()
+	heap.Init(&scheduler.queue)
+	heap.Push(&scheduler.queue, &schedulerEntry{taskID: task.ID, trigger: trigger})
+	scheduler.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		scheduler.tick()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("tick did not return within 2s; a persistently failing entry wedged the scheduler")
+	}
+}
+
+// TestTickDoesNotWedgeOnPersistentAdvanceFailure reproduces a due entry whose
+// trigger can never be advanced (a stored CronTrigger with a now-invalid
+// timezone). Once maxFireAttemptsPerTick is exhausted, skipEntry must drop
+// the entry instead of re-queuing it with its still-due NextRunAt, or tick
+// spins on it forever.
+func TestTickDoesNotWedgeOnPersistentAdvanceFailure(t *testing.T) {
+	storage := newMemoryStorage()
+	tm := NewTaskManager(storage)
+
+	task, err := tm.CreateTask("broken-advance-task", ResourceCreation, Provider{Name: "p"})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	trigger := CronTrigger{
+		TriggerName:    "broken",
+		TriggerType:    cronTrigger,
+		CronExpression: "* * * * *",
+		Timezone:       "Not/AZone",
+		NextRunAt:      time.Now().UTC().Add(-time.Second),
+		Misfire:        FireImmediately,
+	}
+
+	scheduler := NewScheduler(tm)
+	scheduler.mu.Lock()
+	heap.Init(&scheduler.queue)
+	heap.Push(&scheduler.queue, &schedulerEntry{taskID: task.ID, trigger: trigger})
+	scheduler.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		scheduler.tick()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("tick did not return within 2s; an entry whose Advance always fails wedged the scheduler")
+	}
+
+	scheduler.mu.Lock()
+	remaining := scheduler.queue.Len()
+	scheduler.mu.Unlock()
+	if remaining != 0 {
+		t.Fatalf("expected the unadvanceable entry to be dropped, but queue still has %d entries", remaining)
+	}
+}
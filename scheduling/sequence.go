@@ -0,0 +1,75 @@
+package scheduling
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const taskSequenceKey = "/tasks/seq/task_id"
+
+func runSequenceKey(taskID TaskID) string {
+	return fmt.Sprintf("/tasks/seq/run_id/%d", taskID)
+}
+
+// maxSequenceCASAttempts bounds the compare-and-swap retry loop so a
+// pathologically hot counter fails loudly instead of spinning forever.
+const maxSequenceCASAttempts = 100
+
+// SequenceAllocator hands out monotonically increasing, gap-free IDs backed
+// by a single StorageProvider counter key. Unlike scanning ListKeys for the
+// current max, every increment goes through a compare-and-swap loop so two
+// concurrent callers never compute the same "latest+1".
+type SequenceAllocator struct {
+	storage StorageProvider
+}
+
+func NewSequenceAllocator(storage StorageProvider) SequenceAllocator {
+	return SequenceAllocator{storage: storage}
+}
+
+// Next atomically increments the counter at key and returns the new value.
+// The first call against a key no one has allocated from yet returns 1.
+func (s SequenceAllocator) Next(key string) (int, error) {
+	for attempt := 0; attempt < maxSequenceCASAttempts; attempt++ {
+		current, exists, err := s.read(key)
+		if err != nil {
+			return 0, err
+		}
+		next := current + 1
+
+		if !exists {
+			ok, err := s.storage.SetIfNotExists(key, strconv.Itoa(next))
+			if err != nil {
+				return 0, fmt.Errorf("failed to seed sequence %s: %v", key, err)
+			}
+			if ok {
+				return next, nil
+			}
+			continue
+		}
+
+		ok, err := s.storage.CompareAndSwap(key, strconv.Itoa(current), strconv.Itoa(next))
+		if err != nil {
+			return 0, fmt.Errorf("failed to advance sequence %s: %v", key, err)
+		}
+		if ok {
+			return next, nil
+		}
+	}
+	return 0, fmt.Errorf("exceeded %d attempts to allocate from sequence %s", maxSequenceCASAttempts, key)
+}
+
+// read treats any error from the underlying Get as "not seeded yet" rather
+// than a hard failure, since StorageProvider implementations commonly
+// surface a missing key that way rather than an empty, error-free result.
+func (s SequenceAllocator) read(key string) (int, bool, error) {
+	vals, err := s.storage.Get(key, false)
+	if err != nil || len(vals) == 0 {
+		return 0, false, nil
+	}
+	current, err := strconv.Atoi(vals[0])
+	if err != nil {
+		return 0, false, fmt.Errorf("corrupt sequence value at %s: %v", key, err)
+	}
+	return current, true, nil
+}
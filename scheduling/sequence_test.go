@@ -0,0 +1,58 @@
+package scheduling
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSequenceAllocatorConcurrentCreateTask(t *testing.T) {
+	const n = 100
+
+	storage := newMemoryStorage()
+	tm := NewTaskManager(storage)
+
+	ids := make([]TaskID, n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		i := i
+		go func() {
+			defer wg.Done()
+			task, err := tm.CreateTask("task", ResourceCreation, Provider{Name: "p"})
+			if err != nil {
+				t.Errorf("CreateTask failed: %v", err)
+				return
+			}
+			ids[i] = task.ID
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[TaskID]bool, n)
+	for _, id := range ids {
+		if seen[id] {
+			t.Fatalf("task id %d was assigned more than once", id)
+		}
+		seen[id] = true
+	}
+	for id := 1; id <= n; id++ {
+		if !seen[TaskID(id)] {
+			t.Fatalf("task id %d was never assigned, ids should be exactly 1..%d with no gaps", id, n)
+		}
+	}
+}
+
+func TestSequenceAllocatorNext(t *testing.T) {
+	storage := newMemoryStorage()
+	allocator := NewSequenceAllocator(storage)
+
+	for expected := 1; expected <= 5; expected++ {
+		got, err := allocator.Next("/tasks/seq/example")
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		if got != expected {
+			t.Fatalf("expected %d, got %d", expected, got)
+		}
+	}
+}
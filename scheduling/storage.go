@@ -0,0 +1,24 @@
+package scheduling
+
+// StorageProvider is the persistence backend used by TaskManager. Keys are
+// flat strings; Get supports prefix scans so callers can fetch a single
+// record or every record under a path segment.
+type StorageProvider interface {
+	Set(key, value string) error
+	Get(key string, prefix bool) ([]string, error)
+	ListKeys(prefix string) ([]string, error)
+
+	// SetIfNotExists writes value at key only if key is currently absent,
+	// reporting whether the write happened. It is the compare-and-swap
+	// primitive distributed locks use to acquire a lock record.
+	SetIfNotExists(key, value string) (bool, error)
+	// CompareAndDelete removes key only if its current value equals
+	// expectedValue, reporting whether the delete happened. Used to release
+	// or reclaim a lock without clobbering a newer holder's record.
+	CompareAndDelete(key, expectedValue string) (bool, error)
+	// CompareAndSwap writes newValue at key only if its current value
+	// equals expected, reporting whether the swap happened. SequenceAllocator
+	// uses this to increment a counter without two concurrent callers ever
+	// landing on the same value.
+	CompareAndSwap(key, expected, newValue string) (bool, error)
+}
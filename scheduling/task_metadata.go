@@ -0,0 +1,274 @@
+package scheduling
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type TaskID int
+
+type TaskRunID int
+
+type TaskType string
+
+const (
+	ResourceCreation TaskType = "ResourceCreation"
+	HealthCheck      TaskType = "HealthCheck"
+)
+
+type TargetType string
+
+const (
+	ProviderTarget    TargetType = "Provider"
+	NameVariantTarget TargetType = "NameVariant"
+)
+
+// TaskTarget is the resource a TaskMetadata operates on.
+type TaskTarget interface {
+	Type() TargetType
+}
+
+type Provider struct {
+	Name string `json:"name"`
+}
+
+func (p Provider) Type() TargetType {
+	return ProviderTarget
+}
+
+type NameVariant struct {
+	Name    string `json:"name"`
+	Variant string `json:"variant"`
+}
+
+func (nv NameVariant) Type() TargetType {
+	return NameVariantTarget
+}
+
+type Status string
+
+const (
+	Pending   Status = "PENDING"
+	Running   Status = "RUNNING"
+	Success   Status = "SUCCESS"
+	Failed    Status = "FAILED"
+	Cancelled Status = "CANCELLED"
+)
+
+func (s Status) Valid() bool {
+	switch s {
+	case Pending, Running, Success, Failed, Cancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// TaskMetadata describes a task. Schedule is nil for tasks that only ever run
+// on demand; when set to a RecurringTrigger (CronTrigger, IntervalTrigger),
+// the Scheduler materializes TaskRuns for it automatically. RetryPolicy is
+// nil for tasks whose failed runs should stay terminal; when set,
+// SetRunStatus consults it to schedule a retry or give up to the dead-letter
+// index.
+type TaskMetadata struct {
+	ID          TaskID
+	Name        string
+	TaskType    TaskType
+	Target      TaskTarget
+	TargetType  TargetType
+	Schedule    Trigger
+	RetryPolicy *RetryPolicy
+	DateCreated time.Time
+}
+
+type taskMetadataWire struct {
+	ID          TaskID
+	Name        string
+	TaskType    TaskType
+	Target      json.RawMessage
+	TargetType  TargetType
+	Schedule    json.RawMessage `json:"schedule,omitempty"`
+	RetryPolicy *RetryPolicy    `json:"retryPolicy,omitempty"`
+	DateCreated time.Time
+}
+
+func (m *TaskMetadata) Marshal() ([]byte, error) {
+	target, err := json.Marshal(m.Target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal target: %v", err)
+	}
+	var schedule json.RawMessage
+	if m.Schedule != nil {
+		schedule, err = json.Marshal(m.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal schedule: %v", err)
+		}
+	}
+	return json.Marshal(taskMetadataWire{
+		ID:          m.ID,
+		Name:        m.Name,
+		TaskType:    m.TaskType,
+		Target:      target,
+		TargetType:  m.TargetType,
+		Schedule:    schedule,
+		RetryPolicy: m.RetryPolicy,
+		DateCreated: m.DateCreated,
+	})
+}
+
+func (m *TaskMetadata) Unmarshal(data []byte) error {
+	wire := taskMetadataWire{}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return fmt.Errorf("failed to unmarshal task metadata: %v", err)
+	}
+	if wire.Name == "" {
+		return fmt.Errorf("missing field 'name'")
+	}
+	target, err := unmarshalTaskTarget(wire.TargetType, wire.Target)
+	if err != nil {
+		return err
+	}
+	var schedule Trigger
+	if len(wire.Schedule) > 0 {
+		schedule, err = unmarshalTrigger(wire.Schedule)
+		if err != nil {
+			return err
+		}
+	}
+	m.ID = wire.ID
+	m.Name = wire.Name
+	m.TaskType = wire.TaskType
+	m.Target = target
+	m.TargetType = wire.TargetType
+	m.Schedule = schedule
+	m.RetryPolicy = wire.RetryPolicy
+	m.DateCreated = wire.DateCreated
+	return nil
+}
+
+func unmarshalTaskTarget(targetType TargetType, data json.RawMessage) (TaskTarget, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("missing field 'target'")
+	}
+	switch targetType {
+	case ProviderTarget:
+		p := Provider{}
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, err
+		}
+		return p, nil
+	case NameVariantTarget:
+		nv := NameVariant{}
+		if err := json.Unmarshal(data, &nv); err != nil {
+			return nil, err
+		}
+		return nv, nil
+	default:
+		return nil, fmt.Errorf("no such target type: '%s'", targetType)
+	}
+}
+
+type TaskRunSimple struct {
+	RunID       TaskRunID
+	DateCreated time.Time
+}
+
+type TaskRuns struct {
+	TaskID TaskID
+	Runs   []TaskRunSimple
+}
+
+func (r *TaskRuns) Marshal() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+func (r *TaskRuns) Unmarshal(data []byte) error {
+	return json.Unmarshal(data, r)
+}
+
+// TaskRunMetadata is a single materialized run of a TaskMetadata. Trigger
+// records what caused this particular run to exist (a user-initiated
+// OneOffTrigger, or the specific CronTrigger/IntervalTrigger slot it fired
+// for). ParentRunID is nil for a task's first attempt; a retry scheduled by
+// SetRunStatus after a Failed run sets it to the run it's retrying, so the
+// full attempt history can be reconstructed by following the chain.
+type TaskRunMetadata struct {
+	ID          TaskRunID
+	TaskId      TaskID
+	Name        string
+	Trigger     Trigger
+	TriggerType TriggerType
+	Status      Status
+	StartTime   time.Time
+	EndTime     time.Time
+	Logs        []string
+	Error       string
+	ParentRunID *TaskRunID
+}
+
+type taskRunMetadataWire struct {
+	ID          TaskRunID
+	TaskId      TaskID
+	Name        string
+	Trigger     json.RawMessage
+	TriggerType TriggerType
+	Status      Status
+	StartTime   time.Time
+	EndTime     time.Time
+	Logs        []string
+	Error       string
+	ParentRunID *TaskRunID `json:"parentRunId,omitempty"`
+}
+
+func (m *TaskRunMetadata) Marshal() ([]byte, error) {
+	if m.Trigger == nil {
+		return nil, fmt.Errorf("missing field 'trigger'")
+	}
+	trigger, err := json.Marshal(m.Trigger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal trigger: %v", err)
+	}
+	return json.Marshal(taskRunMetadataWire{
+		ID:          m.ID,
+		TaskId:      m.TaskId,
+		Name:        m.Name,
+		Trigger:     trigger,
+		TriggerType: m.TriggerType,
+		Status:      m.Status,
+		StartTime:   m.StartTime,
+		EndTime:     m.EndTime,
+		Logs:        m.Logs,
+		Error:       m.Error,
+		ParentRunID: m.ParentRunID,
+	})
+}
+
+func (m *TaskRunMetadata) Unmarshal(data []byte) error {
+	wire := taskRunMetadataWire{}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return fmt.Errorf("failed to unmarshal task run metadata: %v", err)
+	}
+	if wire.Name == "" {
+		return fmt.Errorf("missing field 'name'")
+	}
+	if !wire.Status.Valid() {
+		return fmt.Errorf("no such status: '%s'", wire.Status)
+	}
+	trigger, err := unmarshalTrigger(wire.Trigger)
+	if err != nil {
+		return err
+	}
+	m.ID = wire.ID
+	m.TaskId = wire.TaskId
+	m.Name = wire.Name
+	m.Trigger = trigger
+	m.TriggerType = wire.TriggerType
+	m.Status = wire.Status
+	m.StartTime = wire.StartTime
+	m.EndTime = wire.EndTime
+	m.Logs = wire.Logs
+	m.Error = wire.Error
+	m.ParentRunID = wire.ParentRunID
+	return nil
+}
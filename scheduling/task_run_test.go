@@ -49,6 +49,51 @@ func TestSerializeTaskRunMetadata(t *testing.T) {
 			},
 			triggerType: "DummyTrigger",
 		},
+		{
+			name: "WithCronTrigger",
+			task: TaskRunMetadata{
+				ID:     1,
+				TaskId: 12,
+				Name:   "cron_taskrun",
+				Trigger: CronTrigger{
+					TriggerName:    "name9",
+					TriggerType:    cronTrigger,
+					CronExpression: "0 * * * *",
+					Timezone:       "UTC",
+					NextRunAt:      time.Now().Truncate(0).UTC(),
+					LastRunAt:      time.Now().Truncate(0).UTC(),
+					Misfire:        SkipMisfire,
+				},
+				Status:    Pending,
+				StartTime: time.Now().Truncate(0).UTC(),
+				EndTime:   time.Now().Truncate(0).UTC(),
+				Logs:      nil,
+				Error:     "",
+			},
+			triggerType: "CronTrigger",
+		},
+		{
+			name: "WithIntervalTrigger",
+			task: TaskRunMetadata{
+				ID:     1,
+				TaskId: 12,
+				Name:   "interval_taskrun",
+				Trigger: IntervalTrigger{
+					TriggerName: "name10",
+					TriggerType: intervalTrigger,
+					Interval:    5 * time.Minute,
+					NextRunAt:   time.Now().Truncate(0).UTC(),
+					LastRunAt:   time.Now().Truncate(0).UTC(),
+					Misfire:     FireImmediately,
+				},
+				Status:    Running,
+				StartTime: time.Now().Truncate(0).UTC(),
+				EndTime:   time.Now().Truncate(0).UTC(),
+				Logs:      nil,
+				Error:     "",
+			},
+			triggerType: "IntervalTrigger",
+		},
 	}
 
 	for _, currTest := range testCases {
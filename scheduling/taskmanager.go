@@ -1,11 +1,11 @@
 package scheduling
 
 import (
-	"context"
 	"fmt"
-	"strconv"
-	"strings"
+	"sync"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type TableKey string
@@ -34,11 +34,30 @@ const (
 )
 
 func NewTaskManager(storage StorageProvider) TaskManager {
-	return TaskManager{storage: storage}
+	return TaskManager{
+		storage:  storage,
+		ownerID:  uuid.New().String(),
+		locks:    &taskLockTable{held: map[TaskRunID]*heldLock{}},
+		sequence: NewSequenceAllocator(storage),
+	}
 }
 
 type TaskManager struct {
 	storage StorageProvider
+	// ownerID identifies this TaskManager instance in lock records so a
+	// refresh can tell its own lease apart from one a concurrent owner just
+	// reclaimed.
+	ownerID  string
+	locks    *taskLockTable
+	sequence SequenceAllocator
+}
+
+// taskLockTable is held behind a pointer on TaskManager so that copying a
+// TaskManager by value (it's returned by value from NewTaskManager) shares
+// the same lock bookkeeping rather than forking it.
+type taskLockTable struct {
+	mu   sync.Mutex
+	held map[TaskRunID]*heldLock
 }
 
 type TaskMetadataList []TaskMetadata
@@ -50,23 +69,13 @@ func (tml *TaskMetadataList) ToJSON() string {
 // Task Methods
 func (tm *TaskManager) CreateTask(name string, tType TaskType, target TaskTarget) (TaskMetadata, error) {
 	// ids will be generated by TM
-	keys, err := tm.storage.ListKeys(TASKMETADATA.ToString())
+	nextID, err := tm.sequence.Next(taskSequenceKey)
 	if err != nil {
-		return TaskMetadata{}, fmt.Errorf("failed to fetch keys: %v", err)
-	}
-
-	var latestID int
-	if len(keys) == 0 {
-		latestID = 0
-	} else {
-		latestID, err = getLatestID(keys)
-		if err != nil {
-			return TaskMetadata{}, err
-		}
+		return TaskMetadata{}, fmt.Errorf("failed to allocate task id: %v", err)
 	}
 
 	metadata := TaskMetadata{
-		ID:          TaskID(latestID + 1),
+		ID:          TaskID(nextID),
 		Name:        name,
 		TaskType:    tType,
 		Target:      target,
@@ -100,28 +109,6 @@ func (tm *TaskManager) CreateTask(name string, tType TaskType, target TaskTarget
 	return metadata, nil
 }
 
-// Finds the highest increment in a list of strings formatted like "/tasks/metadata/task_id=0"
-func getLatestID(taskPaths []string) (int, error) {
-	highestIncrement := -1
-	for _, path := range taskPaths {
-		parts := strings.Split(path, "task_id=")
-		if len(parts) < 2 {
-			return -1, fmt.Errorf("invalid format for path: %s", path)
-		}
-		increment, err := strconv.Atoi(parts[1])
-		if err != nil {
-			return -1, fmt.Errorf("failed to convert task_id to integer: %s", err)
-		}
-		if increment > highestIncrement {
-			highestIncrement = increment
-		}
-	}
-	if highestIncrement == -1 {
-		return -1, fmt.Errorf("no valid increments found")
-	}
-	return highestIncrement, nil
-}
-
 func (tm *TaskManager) GetTaskByID(id TaskID) (TaskMetadata, error) {
 	key := TASKMETADATA.GetTaskMetadataKey(id)
 	metadata, err := tm.storage.Get(key, false)
@@ -168,6 +155,23 @@ func (tm *TaskManager) GetAllTasks() (TaskMetadataList, error) {
 	return tml, nil
 }
 
+// UpdateTaskSchedule persists a new schedule (typically the result of
+// RecurringTrigger.Advance or CatchUp) onto a task's metadata.
+func (tm *TaskManager) UpdateTaskSchedule(id TaskID, trigger Trigger) error {
+	task, err := tm.GetTaskByID(id)
+	if err != nil {
+		return err
+	}
+
+	task.Schedule = trigger
+
+	serializedMetadata, err := task.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %v", err)
+	}
+	return tm.storage.Set(TASKMETADATA.GetTaskMetadataKey(id), string(serializedMetadata))
+}
+
 type TaskRunList []TaskRunMetadata
 
 func (trl *TaskRunList) ToJSON() string {
@@ -186,6 +190,14 @@ func (trl *TaskRunList) FilterByStatus(status Status) {
 
 // Task Run Methods
 func (tm *TaskManager) CreateTaskRun(name string, taskID TaskID, trigger Trigger) (TaskRunMetadata, error) {
+	return tm.createRun(name, taskID, trigger, time.Now().UTC(), nil)
+}
+
+// createRun is the shared implementation behind CreateTaskRun and the retry
+// path in retry.go: the only difference between a fresh run and a retry is
+// the StartTime (immediate vs. delayed by a RetryPolicy backoff) and whether
+// ParentRunID links back to the attempt it's retrying.
+func (tm *TaskManager) createRun(name string, taskID TaskID, trigger Trigger, startTime time.Time, parentRunID *TaskRunID) (TaskRunMetadata, error) {
 	// ids will be generated by TM
 	key, err := tm.storage.Get(fmt.Sprintf("/tasks/runs/task_id=%d", taskID), false)
 	if err != nil {
@@ -198,21 +210,20 @@ func (tm *TaskManager) CreateTaskRun(name string, taskID TaskID, trigger Trigger
 		return TaskRunMetadata{}, err
 	}
 
-	latestID, err := getHighestRunID(runs)
+	nextID, err := tm.sequence.Next(runSequenceKey(taskID))
 	if err != nil {
-		return TaskRunMetadata{}, err
+		return TaskRunMetadata{}, fmt.Errorf("failed to allocate run id: %v", err)
 	}
 
-	startTime := time.Now().UTC()
-
 	metadata := TaskRunMetadata{
-		ID:          TaskRunID(latestID + 1),
+		ID:          TaskRunID(nextID),
 		TaskId:      taskID,
 		Name:        name,
 		Trigger:     trigger,
 		TriggerType: trigger.Type(),
 		Status:      Pending,
 		StartTime:   startTime,
+		ParentRunID: parentRunID,
 	}
 
 	runs.Runs = append(runs.Runs, TaskRunSimple{RunID: metadata.ID, DateCreated: startTime})
@@ -222,38 +233,32 @@ func (tm *TaskManager) CreateTaskRun(name string, taskID TaskID, trigger Trigger
 		return TaskRunMetadata{}, err
 	}
 
-	serializedMetadata, err := metadata.Marshal()
-	if err != nil {
-		return TaskRunMetadata{}, fmt.Errorf("failed to marshal metadata: %v", err)
+	if err := tm.storage.Set(fmt.Sprintf("/tasks/runs/task_id=%d", taskID), string(serializedRuns)); err != nil {
+		return TaskRunMetadata{}, err
 	}
-	err = tm.storage.Set(fmt.Sprintf("/tasks/runs/task_id=%d", taskID), string(serializedRuns))
-	if err != nil {
+
+	if err := tm.writeRunMetadata(metadata); err != nil {
 		return TaskRunMetadata{}, err
 	}
 
-	// Need to double check that date is always 0 padded
-	err = tm.storage.Set(fmt.Sprintf("tasks/runs/metadata/%d/%s/%d/task_id=%d/run_id=%d", startTime.Year(), startTime.Month(), startTime.Day(), taskID, metadata.ID), string(serializedMetadata))
-	if err != nil {
+	if err := tm.writeRunIndexes(metadata); err != nil {
 		return TaskRunMetadata{}, err
 	}
 
 	return metadata, nil
 }
 
-func getHighestRunID(taskRuns TaskRuns) (TaskRunID, error) {
-	if len(taskRuns.Runs) == 0 {
-		return 0, nil
-	}
-
-	highestRunID := taskRuns.Runs[0].RunID
-
-	for _, run := range taskRuns.Runs[1:] {
-		if run.RunID > highestRunID {
-			highestRunID = run.RunID
-		}
+// writeRunMetadata (over)writes a run's primary record at its date-sharded
+// key. Used both for first-write at creation and for in-place updates like
+// SetRunStatus.
+func (tm *TaskManager) writeRunMetadata(run TaskRunMetadata) error {
+	serialized, err := run.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %v", err)
 	}
-
-	return highestRunID, nil
+	// Need to double check that date is always 0 padded
+	key := fmt.Sprintf("tasks/runs/metadata/%d/%s/%d/task_id=%d/run_id=%d", run.StartTime.Year(), run.StartTime.Month(), run.StartTime.Day(), run.TaskId, run.ID)
+	return tm.storage.Set(key, string(serialized))
 }
 
 func (tm *TaskManager) GetRunByID(taskID TaskID, runID TaskRunID) (TaskRunMetadata, error) {
@@ -310,7 +315,7 @@ func (tm *TaskManager) GetRunsByDate(start time.Time, end time.Time) (TaskRunLis
 		if err != nil {
 			return []TaskRunMetadata{}, fmt.Errorf("failed to unmarshal run record: %v", err)
 		}
-		if taskRun.StartTime.After(start) {
+		if taskRun.StartTime.Before(start) || taskRun.StartTime.After(end) {
 			continue
 		}
 		runs = append(runs, taskRun)
@@ -337,33 +342,64 @@ func (tm *TaskManager) GetAllTaskRuns() (TaskRunList, error) {
 }
 
 // Write Methods
-func (t *TaskManager) SetRunStatus(id TaskRunID, status Status, err error) error {
-	// we will need task id as well
-	return fmt.Errorf("Not implemented")
+func (t *TaskManager) SetRunStatus(id TaskRunID, status Status, runErr error) error {
+	if lockErr := t.requireLock(id); lockErr != nil {
+		return lockErr
+	}
+	if !status.Valid() {
+		return fmt.Errorf("no such status: '%s'", status)
+	}
+
+	locator, err := t.locateRun(id)
+	if err != nil {
+		return err
+	}
+	run, err := t.GetRunByID(locator.TaskID, locator.RunID)
+	if err != nil {
+		return err
+	}
+
+	oldStatus := run.Status
+	run.Status = status
+	if runErr != nil {
+		run.Error = runErr.Error()
+	}
+
+	if err := t.writeRunMetadata(run); err != nil {
+		return err
+	}
+	if err := t.updateRunStatusIndex(run, oldStatus); err != nil {
+		return err
+	}
+
+	if status != Failed {
+		return nil
+	}
+	return t.handleFailedRun(run)
 }
 
 func (t *TaskManager) SetRunStartTime(id TaskRunID, time time.Time) error {
+	if lockErr := t.requireLock(id); lockErr != nil {
+		return lockErr
+	}
 	// we will need task id as well
 	return fmt.Errorf("Not implemented")
 }
 
 func (t *TaskManager) SetRunEndTime(id TaskRunID, time time.Time) error {
+	if lockErr := t.requireLock(id); lockErr != nil {
+		return lockErr
+	}
 	// we will need task id as well
 	return fmt.Errorf("Not implemented")
 }
 
 func (t *TaskManager) AppendRunLog(id TaskRunID, log string) error {
+	if lockErr := t.requireLock(id); lockErr != nil {
+		return lockErr
+	}
 	// we will need task id as well
 	return fmt.Errorf("Not implemented")
 }
 
-// Locking
-func (t *TaskManager) LockTaskRun(ctx context.Context, runId TaskRunID) error {
-	// we will need task id as well
-	return fmt.Errorf("Not implemented")
-}
-
-func (t *TaskManager) UnlockTaskRun(ctx context.Context, runId TaskRunID) error {
-	// we will need task id as well
-	return fmt.Errorf("Not implemented")
-}
+// Locking is implemented in lock.go.
@@ -0,0 +1,241 @@
+package scheduling
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+type TriggerType string
+
+const (
+	oneOffTrigger   TriggerType = "OneOffTrigger"
+	dummyTrigger    TriggerType = "DummyTrigger"
+	cronTrigger     TriggerType = "CronTrigger"
+	intervalTrigger TriggerType = "IntervalTrigger"
+)
+
+// Trigger decides when a TaskRun should be created. It is stored on both
+// TaskMetadata (as a recurring schedule) and TaskRunMetadata (as a record of
+// what caused that particular run).
+type Trigger interface {
+	Type() TriggerType
+}
+
+// RecurringTrigger is a Trigger that fires more than once. The Scheduler
+// keeps a min-heap of these keyed by GetNextRunAt.
+type RecurringTrigger interface {
+	Trigger
+	GetNextRunAt() time.Time
+	GetMisfirePolicy() MisfirePolicy
+	// Advance steps the trigger forward by exactly one period from its
+	// current NextRunAt, for normal in-sequence firing.
+	Advance() (RecurringTrigger, error)
+	// CatchUp recomputes NextRunAt as the next slot after now, used when a
+	// trigger was found overdue at startup and its MisfirePolicy is
+	// SkipMisfire.
+	CatchUp(now time.Time) (RecurringTrigger, error)
+}
+
+type OneOffTrigger struct {
+	TriggerName string      `json:"triggerName"`
+	TriggerType TriggerType `json:"triggerType"`
+}
+
+func (t OneOffTrigger) Type() TriggerType {
+	return t.TriggerType
+}
+
+type DummyTrigger struct {
+	TriggerName string      `json:"triggerName"`
+	TriggerType TriggerType `json:"triggerType"`
+	DummyField  bool        `json:"dummyField"`
+}
+
+func (t DummyTrigger) Type() TriggerType {
+	return t.TriggerType
+}
+
+// MisfirePolicy controls what happens when a recurring trigger's NextRunAt
+// has already passed by the time the Scheduler notices it, e.g. because the
+// process was down.
+type MisfirePolicy string
+
+const (
+	// FireImmediately lets the overdue run fire on the next tick, then
+	// resumes the schedule from there.
+	FireImmediately MisfirePolicy = "FIRE_IMMEDIATELY"
+	// SkipMisfire drops the overdue run and jumps straight to the next
+	// future slot.
+	SkipMisfire MisfirePolicy = "SKIP_MISFIRE"
+)
+
+// CronTrigger fires on a cron schedule, evaluated in Timezone so that DST
+// transitions are handled the way the underlying IANA zone defines them.
+type CronTrigger struct {
+	TriggerName    string        `json:"triggerName"`
+	TriggerType    TriggerType   `json:"triggerType"`
+	CronExpression string        `json:"cronExpression"`
+	Timezone       string        `json:"timezone"`
+	NextRunAt      time.Time     `json:"nextRunAt"`
+	LastRunAt      time.Time     `json:"lastRunAt"`
+	Misfire        MisfirePolicy `json:"misfirePolicy"`
+}
+
+// NewCronTrigger parses cronExpr and timezone eagerly so construction fails
+// fast on bad config instead of failing silently on the first scheduler tick.
+func NewCronTrigger(name, cronExpr, timezone string, misfire MisfirePolicy) (CronTrigger, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return CronTrigger{}, fmt.Errorf("invalid timezone %q: %v", timezone, err)
+	}
+	schedule, err := cron.ParseStandard(cronExpr)
+	if err != nil {
+		return CronTrigger{}, fmt.Errorf("invalid cron expression %q: %v", cronExpr, err)
+	}
+	return CronTrigger{
+		TriggerName:    name,
+		TriggerType:    cronTrigger,
+		CronExpression: cronExpr,
+		Timezone:       timezone,
+		NextRunAt:      schedule.Next(time.Now().In(loc)),
+		Misfire:        misfire,
+	}, nil
+}
+
+func (t CronTrigger) Type() TriggerType {
+	return t.TriggerType
+}
+
+func (t CronTrigger) GetNextRunAt() time.Time {
+	return t.NextRunAt
+}
+
+func (t CronTrigger) GetMisfirePolicy() MisfirePolicy {
+	return t.Misfire
+}
+
+func (t CronTrigger) Advance() (RecurringTrigger, error) {
+	schedule, loc, err := t.parse()
+	if err != nil {
+		return nil, err
+	}
+	t.LastRunAt = t.NextRunAt
+	t.NextRunAt = schedule.Next(t.NextRunAt.In(loc))
+	return t, nil
+}
+
+func (t CronTrigger) CatchUp(now time.Time) (RecurringTrigger, error) {
+	schedule, loc, err := t.parse()
+	if err != nil {
+		return nil, err
+	}
+	t.NextRunAt = schedule.Next(now.In(loc))
+	return t, nil
+}
+
+func (t CronTrigger) parse() (cron.Schedule, *time.Location, error) {
+	loc, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid timezone %q: %v", t.Timezone, err)
+	}
+	schedule, err := cron.ParseStandard(t.CronExpression)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid cron expression %q: %v", t.CronExpression, err)
+	}
+	return schedule, loc, nil
+}
+
+// IntervalTrigger fires every Interval, independent of wall-clock alignment.
+type IntervalTrigger struct {
+	TriggerName string        `json:"triggerName"`
+	TriggerType TriggerType   `json:"triggerType"`
+	Interval    time.Duration `json:"interval"`
+	NextRunAt   time.Time     `json:"nextRunAt"`
+	LastRunAt   time.Time     `json:"lastRunAt"`
+	Misfire     MisfirePolicy `json:"misfirePolicy"`
+}
+
+func NewIntervalTrigger(name string, interval time.Duration, misfire MisfirePolicy) (IntervalTrigger, error) {
+	if interval <= 0 {
+		return IntervalTrigger{}, fmt.Errorf("interval must be positive, got %s", interval)
+	}
+	return IntervalTrigger{
+		TriggerName: name,
+		TriggerType: intervalTrigger,
+		Interval:    interval,
+		NextRunAt:   time.Now().UTC().Add(interval),
+		Misfire:     misfire,
+	}, nil
+}
+
+func (t IntervalTrigger) Type() TriggerType {
+	return t.TriggerType
+}
+
+func (t IntervalTrigger) GetNextRunAt() time.Time {
+	return t.NextRunAt
+}
+
+func (t IntervalTrigger) GetMisfirePolicy() MisfirePolicy {
+	return t.Misfire
+}
+
+func (t IntervalTrigger) Advance() (RecurringTrigger, error) {
+	t.LastRunAt = t.NextRunAt
+	t.NextRunAt = t.NextRunAt.Add(t.Interval)
+	return t, nil
+}
+
+func (t IntervalTrigger) CatchUp(now time.Time) (RecurringTrigger, error) {
+	t.NextRunAt = now.Add(t.Interval)
+	return t, nil
+}
+
+// unmarshalTrigger inspects the triggerType field embedded in data and
+// dispatches to the matching concrete Trigger type. It is the single place
+// TaskRunMetadata.Unmarshal and TaskMetadata.Unmarshal go through so new
+// Trigger implementations only need to be registered here.
+func unmarshalTrigger(data []byte) (Trigger, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("missing field 'trigger'")
+	}
+
+	probe := struct {
+		TriggerType TriggerType `json:"triggerType"`
+	}{}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("wrong format of trigger: %v", err)
+	}
+
+	switch probe.TriggerType {
+	case oneOffTrigger:
+		t := OneOffTrigger{}
+		if err := json.Unmarshal(data, &t); err != nil {
+			return nil, err
+		}
+		return t, nil
+	case dummyTrigger:
+		t := DummyTrigger{}
+		if err := json.Unmarshal(data, &t); err != nil {
+			return nil, err
+		}
+		return t, nil
+	case cronTrigger:
+		t := CronTrigger{}
+		if err := json.Unmarshal(data, &t); err != nil {
+			return nil, err
+		}
+		return t, nil
+	case intervalTrigger:
+		t := IntervalTrigger{}
+		if err := json.Unmarshal(data, &t); err != nil {
+			return nil, err
+		}
+		return t, nil
+	default:
+		return nil, fmt.Errorf("no such trigger type: '%s'", probe.TriggerType)
+	}
+}